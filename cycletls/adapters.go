@@ -0,0 +1,137 @@
+package cycletls
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	nethttp "net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// TransferAdapter lets a CycleTLS serve non-HTTP(S) schemes — file://,
+// ssh://, or a custom one — through the same Do/Queue/worker-pool
+// machinery, by registering a RoundTrip for that scheme's URLs instead of
+// going through newClient/dispatcher.
+type TransferAdapter interface {
+	Scheme() string
+	RoundTrip(options Options) (Response, error)
+}
+
+// RegisterAdapter installs adapter for its Scheme(), overwriting any
+// previously registered adapter for that scheme.
+func (client *CycleTLS) RegisterAdapter(adapter TransferAdapter) {
+	if client.adapters == nil {
+		client.adapters = make(map[string]TransferAdapter)
+	}
+	client.adapters[adapter.Scheme()] = adapter
+}
+
+// adapterFor looks up a registered TransferAdapter for rawURL's scheme. A
+// malformed URL falls through (ok == false) to the existing HTTP path,
+// which is responsible for reporting the parse error as it always has.
+func (client *CycleTLS) adapterFor(rawURL string) (TransferAdapter, bool) {
+	if len(client.adapters) == 0 {
+		return nil, false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, false
+	}
+	adapter, ok := client.adapters[u.Scheme]
+	return adapter, ok
+}
+
+// FileAdapter serves file:// URLs straight off disk.
+type FileAdapter struct{}
+
+// Scheme implements TransferAdapter.
+func (FileAdapter) Scheme() string { return "file" }
+
+// RoundTrip implements TransferAdapter.
+func (FileAdapter) RoundTrip(options Options) (Response, error) {
+	u, err := url.Parse(options.URL)
+	if err != nil {
+		return Response{}, err
+	}
+
+	data, err := os.ReadFile(u.Path)
+	if os.IsNotExist(err) {
+		return Response{Status: nethttp.StatusNotFound, Headers: map[string]string{}}, nil
+	}
+	if err != nil {
+		return Response{}, err
+	}
+
+	contentType := mime.TypeByExtension(filepath.Ext(u.Path))
+	if contentType == "" {
+		contentType = nethttp.DetectContentType(data)
+	}
+
+	return Response{
+		Status:  nethttp.StatusOK,
+		Body:    string(data),
+		Headers: map[string]string{"Content-Type": contentType},
+	}, nil
+}
+
+// StdioAdapter shells out to a helper binary and speaks a small JSON-line
+// protocol on its stdin/stdout: one JSON-encoded Options in, one
+// JSON-encoded Response out. Useful for integrating custom
+// TLS-fingerprinting proxies or air-gapped fetchers, or for resolving
+// schemes like ssh:// via an external helper, without modifying CycleTLS.
+type StdioAdapter struct {
+	// SchemeName is the URL scheme this adapter is registered for.
+	SchemeName string
+	// Command is the helper binary invoked once per RoundTrip.
+	Command string
+	Args    []string
+}
+
+// Scheme implements TransferAdapter.
+func (a StdioAdapter) Scheme() string { return a.SchemeName }
+
+// RoundTrip implements TransferAdapter.
+func (a StdioAdapter) RoundTrip(options Options) (Response, error) {
+	cmd := exec.Command(a.Command, a.Args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return Response{}, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return Response{}, err
+	}
+	if err := cmd.Start(); err != nil {
+		return Response{}, err
+	}
+
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return Response{}, err
+	}
+	if _, err := stdin.Write(append(encoded, '\n')); err != nil {
+		return Response{}, err
+	}
+	if err := stdin.Close(); err != nil {
+		return Response{}, err
+	}
+
+	var response Response
+	decodeErr := json.NewDecoder(stdout).Decode(&response)
+	waitErr := cmd.Wait()
+	if decodeErr == io.EOF {
+		return Response{}, fmt.Errorf("cycletls: stdio adapter %q: exited without writing a response", a.Command)
+	}
+	if decodeErr != nil {
+		return Response{}, fmt.Errorf("cycletls: stdio adapter %q: %w", a.Command, decodeErr)
+	}
+	if waitErr != nil {
+		return Response{}, fmt.Errorf("cycletls: stdio adapter %q: %w", a.Command, waitErr)
+	}
+	return response, nil
+}
@@ -0,0 +1,81 @@
+package cycletls
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type stubAdapter struct{ scheme string }
+
+func (s stubAdapter) Scheme() string                      { return s.scheme }
+func (s stubAdapter) RoundTrip(Options) (Response, error) { return Response{}, nil }
+
+func TestAdapterFor(t *testing.T) {
+	client := &CycleTLS{}
+	client.RegisterAdapter(stubAdapter{scheme: "file"})
+
+	t.Run("registered scheme is found", func(t *testing.T) {
+		adapter, ok := client.adapterFor("file:///tmp/x")
+		if !ok || adapter.Scheme() != "file" {
+			t.Errorf("adapterFor(file://) = (%v, %v), want the registered file adapter", adapter, ok)
+		}
+	})
+
+	t.Run("unregistered scheme falls through", func(t *testing.T) {
+		if _, ok := client.adapterFor("https://example.com"); ok {
+			t.Error("adapterFor(https://) = ok, want fall-through to HTTP path")
+		}
+	})
+
+	t.Run("malformed URL falls through", func(t *testing.T) {
+		if _, ok := client.adapterFor("://not-a-url"); ok {
+			t.Error("adapterFor(malformed) = ok, want fall-through")
+		}
+	})
+
+	t.Run("no adapters registered", func(t *testing.T) {
+		empty := &CycleTLS{}
+		if _, ok := empty.adapterFor("file:///tmp/x"); ok {
+			t.Error("adapterFor() on a client with no adapters = ok, want false")
+		}
+	})
+}
+
+func TestFileAdapterRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hello.txt")
+	if err := os.WriteFile(path, []byte("hello world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("existing file", func(t *testing.T) {
+		resp, err := FileAdapter{}.RoundTrip(Options{URL: "file://" + path})
+		if err != nil {
+			t.Fatalf("RoundTrip() error = %v", err)
+		}
+		if resp.Status != http.StatusOK || resp.Body != "hello world" {
+			t.Errorf("RoundTrip() = %+v, want 200/\"hello world\"", resp)
+		}
+		if resp.Headers["Content-Type"] != "text/plain; charset=utf-8" {
+			t.Errorf("Content-Type = %q", resp.Headers["Content-Type"])
+		}
+	})
+
+	t.Run("missing file is a 404, not an error", func(t *testing.T) {
+		resp, err := FileAdapter{}.RoundTrip(Options{URL: "file://" + filepath.Join(dir, "missing.txt")})
+		if err != nil {
+			t.Fatalf("RoundTrip() error = %v", err)
+		}
+		if resp.Status != http.StatusNotFound {
+			t.Errorf("RoundTrip(missing) status = %d, want 404", resp.Status)
+		}
+	})
+}
+
+func TestFileAdapterScheme(t *testing.T) {
+	if got := (FileAdapter{}).Scheme(); got != "file" {
+		t.Errorf("FileAdapter{}.Scheme() = %q, want %q", got, "file")
+	}
+}
@@ -0,0 +1,72 @@
+package cycletls
+
+import (
+	http "github.com/Danny-Dasilva/fhttp"
+	"testing"
+)
+
+func TestChallengeHeaderFor(t *testing.T) {
+	t.Run("407 reads Proxy-Authenticate", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusProxyAuthRequired,
+			Header:     http.Header{"Proxy-Authenticate": {"Negotiate"}, "WWW-Authenticate": {"Basic"}},
+		}
+		if got := challengeHeaderFor(resp); got != "Negotiate" {
+			t.Errorf("challengeHeaderFor(407) = %q, want %q", got, "Negotiate")
+		}
+	})
+
+	t.Run("401 reads WWW-Authenticate", func(t *testing.T) {
+		resp := &http.Response{
+			StatusCode: http.StatusUnauthorized,
+			Header:     http.Header{"WWW-Authenticate": {"Negotiate"}},
+		}
+		if got := challengeHeaderFor(resp); got != "Negotiate" {
+			t.Errorf("challengeHeaderFor(401) = %q, want %q", got, "Negotiate")
+		}
+	})
+}
+
+func TestAuthorizationHeaderFor(t *testing.T) {
+	t.Run("407 wants Proxy-Authorization", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusProxyAuthRequired}
+		if got := authorizationHeaderFor(resp); got != "Proxy-Authorization" {
+			t.Errorf("authorizationHeaderFor(407) = %q, want %q", got, "Proxy-Authorization")
+		}
+	})
+
+	t.Run("401 wants Authorization", func(t *testing.T) {
+		resp := &http.Response{StatusCode: http.StatusUnauthorized}
+		if got := authorizationHeaderFor(resp); got != "Authorization" {
+			t.Errorf("authorizationHeaderFor(401) = %q, want %q", got, "Authorization")
+		}
+	})
+}
+
+func TestNeedsNegotiateResponse(t *testing.T) {
+	cases := []struct {
+		name   string
+		auth   Auth
+		status int
+		header string
+		want   bool
+	}{
+		{"Negotiate 401 with Negotiate challenge", Auth{Scheme: AuthNegotiate}, http.StatusUnauthorized, "Negotiate", true},
+		{"Negotiate 407 with Negotiate challenge", Auth{Scheme: AuthNegotiate}, http.StatusProxyAuthRequired, "Negotiate", true},
+		{"Negotiate scheme but Basic challenge", Auth{Scheme: AuthNegotiate}, http.StatusUnauthorized, "Basic", false},
+		{"Negotiate scheme but 200 response", Auth{Scheme: AuthNegotiate}, http.StatusOK, "Negotiate", false},
+		{"Basic scheme never needs Negotiate", Auth{Scheme: AuthBasic}, http.StatusUnauthorized, "Negotiate", false},
+		{"NTLM scheme never needs Negotiate", Auth{Scheme: AuthNTLM}, http.StatusUnauthorized, "Negotiate", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tc.status,
+				Header:     http.Header{"WWW-Authenticate": {tc.header}, "Proxy-Authenticate": {tc.header}},
+			}
+			if got := needsNegotiateResponse(tc.auth, resp); got != tc.want {
+				t.Errorf("needsNegotiateResponse() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package cycletls
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	nethttp "net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cache is a pluggable store for conditional HTTP caching, attached to a
+// CycleTLS as Cache. Entries are addressed by the opaque key cacheKey
+// produces from a request's method and URL.
+type Cache interface {
+	Get(key string) (*Response, bool)
+	Put(key string, response *Response)
+	Delete(key string)
+}
+
+const (
+	// cacheHeaderStoredAt and cacheHeaderVarySnapshot are bookkeeping
+	// entries CycleTLS stashes in a cached Response's Headers so freshness
+	// and Vary can be evaluated later; finalizeCachedResponse strips them
+	// before a cached Response is handed back to the caller.
+	cacheHeaderStoredAt     = "X-Cycletls-Cache-Stored-At"
+	cacheHeaderVarySnapshot = "X-Cycletls-Cache-Vary-Snapshot"
+
+	// CacheStatusHeader is set to HIT, REVALIDATED or MISS on each Response
+	// that cachedDo returns without error while Cache is configured.
+	// Responses served by a TransferAdapter bypass the cache and do not
+	// carry it.
+	CacheStatusHeader = "X-CycleTLS-Cache"
+)
+
+// FileCache is the default Cache: one JSON file per entry under Dir.
+type FileCache struct {
+	Dir string
+}
+
+// NewFileCache creates a FileCache rooted at dir, creating it if needed.
+func NewFileCache(dir string) (*FileCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileCache{Dir: dir}, nil
+}
+
+func (c *FileCache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get implements Cache.
+func (c *FileCache) Get(key string) (*Response, bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	var response Response
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, false
+	}
+	return &response, true
+}
+
+// Put implements Cache.
+func (c *FileCache) Put(key string, response *Response) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	_ = ioutil.WriteFile(c.path(key), data, 0o644)
+}
+
+// Delete implements Cache.
+func (c *FileCache) Delete(key string) {
+	_ = os.Remove(c.path(key))
+}
+
+// cacheKey identifies a cached entry by method and URL. Vary is handled at
+// lookup time instead of folding it into the key: varyMatches compares the
+// request headers named by a previously stored Vary directive against the
+// values captured when the entry was written.
+func cacheKey(method, url string) string {
+	return strings.ToUpper(method) + "|" + url
+}
+
+// cachedDo applies client.Cache around dispatchWithRetry: a fresh hit is
+// returned without touching the network, a stale-but-validatable entry is
+// revalidated with If-None-Match/If-Modified-Since, and everything else is
+// a plain miss. A 200 response without Cache-Control no-store or private
+// and without Vary: * is stored for next time.
+func (client *CycleTLS) cachedDo(request cycleTLSRequest) (Response, error) {
+	if client.Cache == nil {
+		return client.dispatchWithRetry(request)
+	}
+
+	key := cacheKey(request.Options.Method, request.Options.URL)
+	entry, ok := client.Cache.Get(key)
+	if ok && !varyMatches(*entry, request.Options.Headers) {
+		entry, ok = nil, false
+	}
+
+	if ok {
+		if fresh, _ := cacheFreshness(*entry); fresh {
+			return finalizeCachedResponse(*entry, "HIT"), nil
+		}
+		request.Options.Headers = revalidationHeaders(*entry, request.Options.Headers)
+	}
+
+	response, err := client.dispatchWithRetry(request)
+	if err != nil {
+		return response, err
+	}
+
+	if ok && response.Status == nethttp.StatusNotModified {
+		merged := *entry
+		merged.Headers = cloneHeaders(entry.Headers)
+		for k, v := range response.Headers {
+			merged.Headers[k] = v
+		}
+		merged.Headers[cacheHeaderStoredAt] = time.Now().UTC().Format(time.RFC3339)
+		client.Cache.Put(key, &merged)
+		return finalizeCachedResponse(merged, "REVALIDATED"), nil
+	}
+
+	cacheControl := response.Headers["Cache-Control"]
+	if response.Status == nethttp.StatusOK && !cacheControlHas(cacheControl, "no-store") && !cacheControlHas(cacheControl, "private") && !varyIsWildcard(response.Headers) {
+		toStore := buildCacheEntry(response, request.Options.Headers)
+		client.Cache.Put(key, &toStore)
+	}
+
+	response.Headers[CacheStatusHeader] = "MISS"
+	return response, nil
+}
+
+// revalidationHeaders augments requestHeaders with If-None-Match/
+// If-Modified-Since from entry's validators, so a stale entry triggers a
+// conditional GET instead of a plain re-fetch. Header names are looked up
+// in their net/http-canonical form ("Etag", not "ETag"), matching how
+// resp.Header populates entry.Headers in the first place.
+func revalidationHeaders(entry Response, requestHeaders map[string]string) map[string]string {
+	if etag := entry.Headers["Etag"]; etag != "" {
+		requestHeaders = withHeader(requestHeaders, "If-None-Match", etag)
+	}
+	if lastModified := entry.Headers["Last-Modified"]; lastModified != "" {
+		requestHeaders = withHeader(requestHeaders, "If-Modified-Since", lastModified)
+	}
+	return requestHeaders
+}
+
+func buildCacheEntry(response Response, requestHeaders map[string]string) Response {
+	entry := response
+	entry.Headers = cloneHeaders(response.Headers)
+
+	snapshot := make(map[string]string)
+	for _, name := range varyList(response.Headers) {
+		snapshot[name] = requestHeaders[name]
+	}
+	snapshotJSON, _ := json.Marshal(snapshot)
+	entry.Headers[cacheHeaderVarySnapshot] = string(snapshotJSON)
+	entry.Headers[cacheHeaderStoredAt] = time.Now().UTC().Format(time.RFC3339)
+	return entry
+}
+
+func cacheFreshness(entry Response) (fresh bool, age time.Duration) {
+	storedAt, err := time.Parse(time.RFC3339, entry.Headers[cacheHeaderStoredAt])
+	if err != nil {
+		return false, 0
+	}
+	age = time.Since(storedAt)
+
+	if maxAge, ok := parseMaxAge(entry.Headers["Cache-Control"]); ok {
+		return age < time.Duration(maxAge)*time.Second, age
+	}
+	if expires := entry.Headers["Expires"]; expires != "" {
+		if when, err := nethttp.ParseTime(expires); err == nil {
+			return time.Now().Before(when), age
+		}
+	}
+	return false, age
+}
+
+func parseMaxAge(cacheControl string) (int, bool) {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.TrimSpace(directive)
+		if strings.HasPrefix(directive, "max-age=") {
+			if seconds, err := strconv.Atoi(strings.TrimPrefix(directive, "max-age=")); err == nil {
+				return seconds, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func cacheControlHas(cacheControl, directive string) bool {
+	for _, d := range strings.Split(cacheControl, ",") {
+		if strings.TrimSpace(d) == directive {
+			return true
+		}
+	}
+	return false
+}
+
+func varyList(headers map[string]string) []string {
+	vary := headers["Vary"]
+	if vary == "" {
+		return nil
+	}
+	var names []string
+	for _, name := range strings.Split(vary, ",") {
+		names = append(names, strings.TrimSpace(name))
+	}
+	return names
+}
+
+// varyIsWildcard reports whether headers carries Vary: *, which per RFC
+// 7231 7.1.4 means the response must never be served from cache.
+func varyIsWildcard(headers map[string]string) bool {
+	for _, name := range varyList(headers) {
+		if name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func varyMatches(entry Response, requestHeaders map[string]string) bool {
+	if varyIsWildcard(entry.Headers) {
+		return false
+	}
+
+	snapshotJSON := entry.Headers[cacheHeaderVarySnapshot]
+	if snapshotJSON == "" {
+		return true
+	}
+	var snapshot map[string]string
+	if err := json.Unmarshal([]byte(snapshotJSON), &snapshot); err != nil {
+		return true
+	}
+	for name, value := range snapshot {
+		if requestHeaders[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneHeaders(headers map[string]string) map[string]string {
+	cloned := make(map[string]string, len(headers))
+	for k, v := range headers {
+		cloned[k] = v
+	}
+	return cloned
+}
+
+func withHeader(headers map[string]string, name, value string) map[string]string {
+	cloned := cloneHeaders(headers)
+	cloned[name] = value
+	return cloned
+}
+
+// finalizeCachedResponse strips CycleTLS's internal bookkeeping headers
+// from entry and tags it with CacheStatusHeader before it reaches a caller.
+func finalizeCachedResponse(entry Response, status string) Response {
+	headers := make(map[string]string, len(entry.Headers))
+	for k, v := range entry.Headers {
+		if k == cacheHeaderStoredAt || k == cacheHeaderVarySnapshot {
+			continue
+		}
+		headers[k] = v
+	}
+	headers[CacheStatusHeader] = status
+	return Response{RequestID: entry.RequestID, Status: entry.Status, Body: entry.Body, Headers: headers}
+}
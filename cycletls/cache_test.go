@@ -0,0 +1,125 @@
+package cycletls
+
+import (
+	nethttp "net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheFreshness(t *testing.T) {
+	t.Run("within max-age is fresh", func(t *testing.T) {
+		entry := buildCacheEntry(Response{Headers: map[string]string{"Cache-Control": "max-age=3600"}}, nil)
+		fresh, _ := cacheFreshness(entry)
+		if !fresh {
+			t.Error("expected entry within max-age to be fresh")
+		}
+	})
+
+	t.Run("past max-age is stale", func(t *testing.T) {
+		entry := buildCacheEntry(Response{Headers: map[string]string{"Cache-Control": "max-age=0"}}, nil)
+		time.Sleep(time.Millisecond)
+		fresh, _ := cacheFreshness(entry)
+		if fresh {
+			t.Error("expected entry past max-age to be stale")
+		}
+	})
+
+	t.Run("expires in the future is fresh", func(t *testing.T) {
+		when := time.Now().Add(time.Hour).UTC().Format(nethttp.TimeFormat)
+		entry := buildCacheEntry(Response{Headers: map[string]string{"Expires": when}}, nil)
+		fresh, _ := cacheFreshness(entry)
+		if !fresh {
+			t.Error("expected entry with future Expires to be fresh")
+		}
+	})
+
+	t.Run("no freshness directive is stale", func(t *testing.T) {
+		entry := buildCacheEntry(Response{Headers: map[string]string{}}, nil)
+		fresh, _ := cacheFreshness(entry)
+		if fresh {
+			t.Error("expected entry with no Cache-Control/Expires to be stale")
+		}
+	})
+}
+
+func TestVaryMatches(t *testing.T) {
+	entry := buildCacheEntry(Response{Headers: map[string]string{"Vary": "Accept-Language"}}, map[string]string{"Accept-Language": "en-US"})
+
+	if !varyMatches(entry, map[string]string{"Accept-Language": "en-US"}) {
+		t.Error("expected matching Accept-Language to satisfy Vary")
+	}
+	if varyMatches(entry, map[string]string{"Accept-Language": "fr-FR"}) {
+		t.Error("expected differing Accept-Language to fail Vary")
+	}
+}
+
+func TestCacheControlHas(t *testing.T) {
+	cases := []struct {
+		cacheControl string
+		directive    string
+		want         bool
+	}{
+		{"no-store", "no-store", true},
+		{"max-age=60, private", "private", true},
+		{"max-age=60", "no-store", false},
+		{"", "no-store", false},
+	}
+	for _, tc := range cases {
+		if got := cacheControlHas(tc.cacheControl, tc.directive); got != tc.want {
+			t.Errorf("cacheControlHas(%q, %q) = %v, want %v", tc.cacheControl, tc.directive, got, tc.want)
+		}
+	}
+}
+
+func TestRevalidationHeaders(t *testing.T) {
+	t.Run("Etag becomes If-None-Match", func(t *testing.T) {
+		entry := Response{Headers: map[string]string{"Etag": `"abc"`}}
+		got := revalidationHeaders(entry, map[string]string{})
+		if got["If-None-Match"] != `"abc"` {
+			t.Errorf("revalidationHeaders If-None-Match = %q, want %q", got["If-None-Match"], `"abc"`)
+		}
+	})
+
+	t.Run("Last-Modified becomes If-Modified-Since", func(t *testing.T) {
+		entry := Response{Headers: map[string]string{"Last-Modified": "Mon, 02 Jan 2006 15:04:05 GMT"}}
+		got := revalidationHeaders(entry, map[string]string{})
+		if got["If-Modified-Since"] != "Mon, 02 Jan 2006 15:04:05 GMT" {
+			t.Errorf("revalidationHeaders If-Modified-Since = %q", got["If-Modified-Since"])
+		}
+	})
+
+	t.Run("no validators leaves headers untouched", func(t *testing.T) {
+		entry := Response{Headers: map[string]string{}}
+		got := revalidationHeaders(entry, map[string]string{"Accept": "*/*"})
+		if len(got) != 1 || got["Accept"] != "*/*" {
+			t.Errorf("revalidationHeaders() = %v, want unchanged", got)
+		}
+	})
+}
+
+func TestVaryWildcard(t *testing.T) {
+	entry := buildCacheEntry(Response{Headers: map[string]string{"Vary": "*"}}, nil)
+
+	if varyMatches(entry, map[string]string{}) {
+		t.Error("expected Vary: * to never match, forcing a MISS")
+	}
+}
+
+func TestParseMaxAge(t *testing.T) {
+	cases := []struct {
+		cacheControl string
+		want         int
+		wantOK       bool
+	}{
+		{"max-age=3600", 3600, true},
+		{"no-store", 0, false},
+		{"public, max-age=0", 0, true},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		got, ok := parseMaxAge(tc.cacheControl)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("parseMaxAge(%q) = (%v, %v), want (%v, %v)", tc.cacheControl, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package cycletls
+
+import (
+	"encoding/json"
+	http "github.com/Danny-Dasilva/fhttp"
+	"golang.org/x/net/publicsuffix"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// storedCookie pairs a cookie with the host/expiry bookkeeping PersistentCookieJar needs for RFC 6265 matching.
+type storedCookie struct {
+	Cookie *http.Cookie
+	// Host is the exact request host a host-only cookie was set for.
+	Host string
+	// ExpireAt is when this cookie dies; zero means no automatic expiry.
+	ExpireAt time.Time
+}
+
+func (s *storedCookie) expired() bool {
+	return !s.ExpireAt.IsZero() && !s.ExpireAt.After(time.Now())
+}
+
+// scope identifies a cookie slot: the Domain attribute for a domain cookie, or the exact host otherwise.
+func (s *storedCookie) scope() string {
+	if s.Cookie.Domain != "" {
+		return s.Cookie.Domain
+	}
+	return s.Host
+}
+
+// PersistentCookieJar is an http.CookieJar with RFC 6265 domain/expiry matching, savable to a JSON file.
+type PersistentCookieJar struct {
+	mu      sync.Mutex
+	cookies map[string][]*storedCookie
+}
+
+// NewCookieJar creates an empty PersistentCookieJar.
+func NewCookieJar() *PersistentCookieJar {
+	return &PersistentCookieJar{cookies: make(map[string][]*storedCookie)}
+}
+
+// LoadCookieJar reads a jar previously written by Save.
+func LoadCookieJar(path string) (*PersistentCookieJar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var stored map[string][]*storedCookie
+	if err := json.NewDecoder(f).Decode(&stored); err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		stored = make(map[string][]*storedCookie)
+	}
+	return &PersistentCookieJar{cookies: stored}, nil
+}
+
+// Save writes the jar's contents to path as JSON.
+func (j *PersistentCookieJar) Save(path string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(j.cookies)
+}
+
+func registrableDomain(u *url.URL) string {
+	host := u.Hostname()
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// domainMatches reports whether requestHost satisfies a cookie's Domain attribute per RFC 6265 6.1.
+func domainMatches(cookieDomain, requestHost string) bool {
+	cookieDomain = strings.ToLower(strings.TrimPrefix(cookieDomain, "."))
+	requestHost = strings.ToLower(requestHost)
+	return cookieDomain == requestHost || strings.HasSuffix(requestHost, "."+cookieDomain)
+}
+
+// expiryOf computes cookie's absolute expiry, honoring Max-Age over Expires per RFC 6265 4.1.2.2.
+func expiryOf(cookie *http.Cookie) time.Time {
+	if cookie.MaxAge < 0 {
+		return time.Unix(0, 0) // already expired: Max-Age=0 deletion
+	}
+	if cookie.MaxAge > 0 {
+		return time.Now().Add(time.Duration(cookie.MaxAge) * time.Second)
+	}
+	if !cookie.Expires.IsZero() {
+		return cookie.Expires
+	}
+	return time.Time{}
+}
+
+// SetCookies implements http.CookieJar.
+func (j *PersistentCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key := registrableDomain(u)
+	bucket := j.cookies[key]
+	for _, cookie := range cookies {
+		stored := &storedCookie{Cookie: cookie, ExpireAt: expiryOf(cookie)}
+		if cookie.Domain == "" {
+			stored.Host = u.Hostname()
+		}
+
+		bucket = removeCookie(bucket, cookie.Name, cookie.Path, stored.scope())
+		if !stored.expired() {
+			bucket = append(bucket, stored)
+		}
+	}
+	j.cookies[key] = bucket
+}
+
+// Cookies implements http.CookieJar.
+func (j *PersistentCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	key := registrableDomain(u)
+	host := u.Hostname()
+
+	var matched []*http.Cookie
+	var live []*storedCookie
+	for _, stored := range j.cookies[key] {
+		if stored.expired() {
+			continue
+		}
+		live = append(live, stored)
+
+		if stored.Cookie.Domain == "" {
+			if !strings.EqualFold(stored.Host, host) {
+				continue
+			}
+		} else if !domainMatches(stored.Cookie.Domain, host) {
+			continue
+		}
+		if stored.Cookie.Secure && u.Scheme != "https" {
+			continue
+		}
+		if stored.Cookie.Path != "" && stored.Cookie.Path != "/" && !strings.HasPrefix(u.Path, stored.Cookie.Path) {
+			continue
+		}
+		matched = append(matched, stored.Cookie)
+	}
+	j.cookies[key] = live
+	return matched
+}
+
+func removeCookie(bucket []*storedCookie, name, path, scope string) []*storedCookie {
+	out := bucket[:0]
+	for _, existing := range bucket {
+		if existing.Cookie.Name == name && existing.Cookie.Path == path && existing.scope() == scope {
+			continue
+		}
+		out = append(out, existing)
+	}
+	return out
+}
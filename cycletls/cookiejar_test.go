@@ -0,0 +1,107 @@
+package cycletls
+
+import (
+	http "github.com/Danny-Dasilva/fhttp"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDomainMatches(t *testing.T) {
+	cases := []struct {
+		name         string
+		cookieDomain string
+		requestHost  string
+		wantMatch    bool
+	}{
+		{"exact", "example.com", "example.com", true},
+		{"leading dot", ".example.com", "example.com", true},
+		{"subdomain", "example.com", "sub.example.com", true},
+		{"unrelated suffix", "example.com", "evilexample.com", false},
+		{"sibling subdomain", "sub1.example.com", "sub2.example.com", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := domainMatches(tc.cookieDomain, tc.requestHost); got != tc.wantMatch {
+				t.Errorf("domainMatches(%q, %q) = %v, want %v", tc.cookieDomain, tc.requestHost, got, tc.wantMatch)
+			}
+		})
+	}
+}
+
+func TestExpiryOf(t *testing.T) {
+	now := time.Now()
+
+	t.Run("max-age negative deletes immediately", func(t *testing.T) {
+		got := expiryOf(&http.Cookie{MaxAge: -1})
+		if !got.After(time.Unix(-1, 0)) || got.After(now) {
+			t.Errorf("expiryOf with MaxAge<0 = %v, want a time in the past", got)
+		}
+	})
+
+	t.Run("max-age wins over expires", func(t *testing.T) {
+		got := expiryOf(&http.Cookie{MaxAge: 60, Expires: now.Add(24 * time.Hour)})
+		if got.After(now.Add(61*time.Second)) || got.Before(now.Add(59*time.Second)) {
+			t.Errorf("expiryOf = %v, want ~60s from now", got)
+		}
+	})
+
+	t.Run("expires used when no max-age", func(t *testing.T) {
+		want := now.Add(time.Hour)
+		got := expiryOf(&http.Cookie{Expires: want})
+		if !got.Equal(want) {
+			t.Errorf("expiryOf = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("session cookie has zero expiry", func(t *testing.T) {
+		got := expiryOf(&http.Cookie{})
+		if !got.IsZero() {
+			t.Errorf("expiryOf for session cookie = %v, want zero", got)
+		}
+	})
+}
+
+func TestPersistentCookieJarHostOnlyScoping(t *testing.T) {
+	jar := NewCookieJar()
+
+	sub1, _ := url.Parse("https://sub1.example.com/")
+	jar.SetCookies(sub1, []*http.Cookie{{Name: "session", Value: "abc", Path: "/"}})
+
+	sub2, _ := url.Parse("https://sub2.example.com/")
+	if got := jar.Cookies(sub2); len(got) != 0 {
+		t.Errorf("host-only cookie from sub1 leaked to sub2: %v", got)
+	}
+
+	if got := jar.Cookies(sub1); len(got) != 1 || got[0].Value != "abc" {
+		t.Errorf("Cookies(sub1) = %v, want the cookie set for sub1", got)
+	}
+}
+
+func TestPersistentCookieJarDomainCookieSharedAcrossSubdomains(t *testing.T) {
+	jar := NewCookieJar()
+
+	sub1, _ := url.Parse("https://sub1.example.com/")
+	jar.SetCookies(sub1, []*http.Cookie{{Name: "session", Value: "abc", Path: "/", Domain: "example.com"}})
+
+	sub2, _ := url.Parse("https://sub2.example.com/")
+	got := jar.Cookies(sub2)
+	if len(got) != 1 || got[0].Value != "abc" {
+		t.Errorf("Cookies(sub2) = %v, want the explicit Domain=example.com cookie", got)
+	}
+}
+
+func TestPersistentCookieJarExpiry(t *testing.T) {
+	jar := NewCookieJar()
+	u, _ := url.Parse("https://example.com/")
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "a", Value: "1", Path: "/", MaxAge: -1}})
+	if got := jar.Cookies(u); len(got) != 0 {
+		t.Errorf("Max-Age=0 cookie replayed: %v", got)
+	}
+
+	jar.SetCookies(u, []*http.Cookie{{Name: "b", Value: "2", Path: "/", Expires: time.Now().Add(-time.Hour)}})
+	if got := jar.Cookies(u); len(got) != 0 {
+		t.Errorf("already-expired cookie replayed: %v", got)
+	}
+}
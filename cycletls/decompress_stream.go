@@ -0,0 +1,53 @@
+package cycletls
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"github.com/andybalholm/brotli"
+	"io"
+)
+
+// multiCloser closes both the decompression reader and the underlying
+// transport body it wraps, in that order, so closing a StreamResponse.Body
+// always releases the connection even if the caller only read part of it.
+type multiCloser struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (m multiCloser) Close() error {
+	var firstErr error
+	for _, c := range m.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// DecompressStream wraps body in the appropriate decompressing
+// io.ReadCloser for the given Content-Encoding, mirroring DecompressBody's
+// encoding detection but without reading the whole response into memory
+// first.
+func DecompressStream(body io.ReadCloser, encoding []string, content []string) (io.ReadCloser, error) {
+	if len(encoding) == 0 {
+		return body, nil
+	}
+
+	switch encoding[0] {
+	case "gzip":
+		gzipReader, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		return multiCloser{Reader: gzipReader, closers: []io.Closer{gzipReader, body}}, nil
+	case "deflate":
+		flateReader := flate.NewReader(body)
+		return multiCloser{Reader: flateReader, closers: []io.Closer{flateReader, body}}, nil
+	case "br":
+		brotliReader := brotli.NewReader(body)
+		return multiCloser{Reader: brotliReader, closers: []io.Closer{body}}, nil
+	default:
+		return body, nil
+	}
+}
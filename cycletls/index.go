@@ -1,9 +1,9 @@
 package cycletls
 
 import (
+	"context"
 	"encoding/json"
 	http "github.com/Danny-Dasilva/fhttp"
-	"io/ioutil"
 	"log"
 	"net/url"
 	"strings"
@@ -23,6 +23,17 @@ type Options struct {
 	DisableRedirect bool              `json:"disableRedirect"`
 	HeaderOrder     []string          `json:"headerOrder"`
 	OrderAsProvided bool              `json:"orderAsProvided"` //TODO
+	HTTP3           bool              `json:"http3"`
+	QUICFingerprint string            `json:"quicFingerprint"`
+	// Context, if set, cancels the request and unblocks any in-progress
+	// DoStream read when it is done. It is not serializable and is
+	// therefore excluded from the JSON wire format.
+	Context context.Context `json:"-"`
+	// Retry configures automatic retries of transient failures for Do.
+	Retry Retry `json:"retry"`
+	// Auth configures Basic/Bearer/Negotiate/NTLM authentication against a
+	// challenging proxy or origin; see the Auth type.
+	Auth Auth `json:"auth"`
 }
 
 type cycleTLSRequest struct {
@@ -32,9 +43,10 @@ type cycleTLSRequest struct {
 
 //rename to request+client+options
 type fullRequest struct {
-	req     *http.Request
-	client  *http.Client
-	options cycleTLSRequest
+	req         *http.Request
+	client      *http.Client
+	options     cycleTLSRequest
+	altSvcCache *altSvcCache
 }
 
 //Response contains Cycletls response data
@@ -60,44 +72,20 @@ type CycleTLS struct {
 	ReqChan  chan fullRequest
 	RespChan chan Response
 
-	cacheClients map[string]*http.Client
-}
-
-func dispatcher(res fullRequest) (response Response, err error) {
-	resp, err := res.client.Do(res.req)
-	if err != nil {
-
-		parsedError := parseError(err)
-
-		headers := make(map[string]string)
-		return Response{res.options.RequestID, parsedError.StatusCode, parsedError.ErrorMsg + "-> \n" + string(err.Error()), headers}, nil //normally return error here
-
-	}
-	defer resp.Body.Close()
-
-	encoding := resp.Header["Content-Encoding"]
-	content := resp.Header["Content-Type"]
+	// CookieJar, if set, is shared by every *http.Client this CycleTLS creates. See NewCookieJar.
+	CookieJar http.CookieJar
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		log.Print("Parse Bytes" + err.Error())
-		return response, err
-	}
+	// Cache, if set, is consulted before every Do dispatch and populated
+	// from responses; see FileCache for the default filesystem-backed
+	// implementation.
+	Cache Cache
 
-	Body := DecompressBody(bodyBytes, encoding, content)
-	headers := make(map[string]string)
-
-	for name, values := range resp.Header {
-		if name == "Set-Cookie" {
-			headers[name] = strings.Join(values, "/,/")
-		} else {
-			for _, value := range values {
-				headers[name] = value
-			}
-		}
-	}
-	return Response{res.options.RequestID, resp.StatusCode, Body, headers}, nil
+	// adapters routes URLs whose scheme has a registered TransferAdapter
+	// around the HTTP(S) client path entirely. See RegisterAdapter.
+	adapters map[string]TransferAdapter
 
+	cacheClients map[clientCacheKey]*http.Client
+	altSvcCache  *altSvcCache
 }
 
 // Queue queues request in worker pool
@@ -107,25 +95,36 @@ func (client *CycleTLS) Queue(URL string, options Options, Method string) {
 	options.Method = Method
 	//TODO add timestamp to request
 	opt := cycleTLSRequest{"Queued Request", options}
-	response := client.processRequest(opt)
-	client.ReqChan <- response
+
+	if adapter, ok := client.adapterFor(opt.Options.URL); ok {
+		go func() {
+			response, err := adapter.RoundTrip(opt.Options)
+			if err != nil {
+				log.Print("Request Failed: " + err.Error())
+			}
+			client.RespChan <- response
+		}()
+		return
+	}
+
+	// The worker reruns processRequest itself (via cachedDo) so Cache and
+	// Retry apply; only options needs to cross the channel.
+	client.ReqChan <- fullRequest{options: opt}
 }
 
-// Do creates a single request
+// Do creates a single request, consulting Cache and retrying transient
+// failures per options.Retry
 func (client *CycleTLS) Do(URL string, options Options, Method string) (response Response, err error) {
 
 	options.URL = URL
 	options.Method = Method
 	opt := cycleTLSRequest{"cycleTLSRequest", options}
 
-	res := client.processRequest(opt)
-	response, err = dispatcher(res)
-	if err != nil {
-		log.Print("Request Failed: " + err.Error())
-		return response, err
+	if adapter, ok := client.adapterFor(opt.Options.URL); ok {
+		return adapter.RoundTrip(opt.Options)
 	}
 
-	return response, nil
+	return client.cachedDo(opt)
 }
 
 // ready Request
@@ -140,25 +139,52 @@ func (client *CycleTLS) processRequest(request cycleTLSRequest) (result fullRequ
 	if err != nil {
 		panic(err)
 	}
-	c, ok := client.cacheClients[urlInfo.Host]
+
+	protocol := protocolH2
+	if request.Options.HTTP3 || client.altSvcCache.has(urlInfo.Hostname()) {
+		protocol = protocolH3
+	}
+	cacheKey := clientCacheKey{Host: urlInfo.Host, Protocol: protocol}
+
+	c, ok := client.cacheClients[cacheKey]
 	if !ok {
-		c, err = newClient(
-			browser,
-			request.Options.Timeout,
-			request.Options.DisableRedirect,
-			request.Options.UserAgent,
-			request.Options.Proxy,
-		)
+		if protocol == protocolH3 {
+			c, err = newQUICClient(
+				browser,
+				request.Options.Timeout,
+				request.Options.DisableRedirect,
+				request.Options.UserAgent,
+				request.Options.Proxy,
+				request.Options.QUICFingerprint,
+			)
+		} else {
+			c, err = newClient(
+				browser,
+				request.Options.Timeout,
+				request.Options.DisableRedirect,
+				request.Options.UserAgent,
+				request.Options.Proxy,
+			)
+		}
 		if err != nil {
 			log.Fatal(err)
 		}
-		client.cacheClients[urlInfo.Host] = c
+		if client.CookieJar != nil {
+			c.Jar = client.CookieJar
+		}
+		client.cacheClients[cacheKey] = c
+	}
+	if request.Options.Auth.Scheme == AuthNTLM {
+		wireNTLM(c)
 	}
 
 	req, err := http.NewRequest(strings.ToUpper(request.Options.Method), request.Options.URL, strings.NewReader(request.Options.Body))
 	if err != nil {
 		log.Fatal(err)
 	}
+	if request.Options.Context != nil {
+		req = req.WithContext(request.Options.Context)
+	}
 	var headerOrder []string
 	//master header order, all your headers will be ordered based on this list and anything extra will be appended to the end
 	//if your site has any custom headers, see the header order chrome uses and then add those headers to this list
@@ -232,22 +258,25 @@ func (client *CycleTLS) processRequest(request cycleTLSRequest) (result fullRequ
 	}
 	req.Header.Set("Host", u.Host)
 	req.Header.Set("user-agent", request.Options.UserAgent)
-	return fullRequest{req: req, client: c, options: request}
+	applyPreemptiveAuth(req, request.Options.Auth)
+	return fullRequest{req: req, client: c, options: request, altSvcCache: client.altSvcCache}
 }
 
 //TODO rename this
 
 // Init starts the worker pool or returns a empty cycletls struct
 func Init(workers ...bool) CycleTLS {
+	client := CycleTLS{
+		cacheClients: make(map[clientCacheKey]*http.Client),
+		altSvcCache:  newAltSvcCache(),
+	}
 	if len(workers) > 0 && workers[0] {
-		reqChan := make(chan fullRequest)
-		respChan := make(chan Response)
-		go workerPool(reqChan, respChan)
+		client.ReqChan = make(chan fullRequest)
+		client.RespChan = make(chan Response)
+		go workerPool(&client, client.ReqChan, client.RespChan)
 		log.Println("Worker Pool Started")
-
-		return CycleTLS{ReqChan: reqChan, RespChan: respChan}
 	}
-	return CycleTLS{cacheClients: make(map[string]*http.Client)}
+	return client
 }
 
 // Close closes channels
@@ -258,17 +287,17 @@ func (client *CycleTLS) Close() {
 }
 
 // Worker Pool
-func workerPool(reqChan chan fullRequest, respChan chan Response) {
+func workerPool(client *CycleTLS, reqChan chan fullRequest, respChan chan Response) {
 	//MAX
 	for i := 0; i < 100; i++ {
-		go worker(reqChan, respChan)
+		go worker(client, reqChan, respChan)
 	}
 }
 
 // Worker
-func worker(reqChan chan fullRequest, respChan chan Response) {
+func worker(client *CycleTLS, reqChan chan fullRequest, respChan chan Response) {
 	for res := range reqChan {
-		response, err := dispatcher(res)
+		response, err := client.cachedDo(res.options)
 		if err != nil {
 			log.Print("Request Failed: " + err.Error())
 		}
@@ -0,0 +1,78 @@
+package cycletls
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestHasH3AltSvc(t *testing.T) {
+	cases := []struct {
+		name   string
+		altSvc string
+		want   bool
+	}{
+		{"h3", `h3=":443"; ma=86400`, true},
+		{"h3 draft version", `h3-29=":443"; ma=86400`, true},
+		{"h3 among others", `h2=":443"; ma=86400, h3=":443"; ma=86400`, true},
+		{"no h3", `h2=":443"; ma=86400`, false},
+		{"empty", "", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := hasH3AltSvc(tc.altSvc); got != tc.want {
+				t.Errorf("hasH3AltSvc(%q) = %v, want %v", tc.altSvc, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuicConfigFor(t *testing.T) {
+	t.Run("known profile", func(t *testing.T) {
+		got := quicConfigFor("firefox")
+		want := quicProfiles["firefox"]
+		if got.MaxIdleTimeout != want.maxIdleTimeout {
+			t.Errorf("quicConfigFor(firefox).MaxIdleTimeout = %v, want %v", got.MaxIdleTimeout, want.maxIdleTimeout)
+		}
+	})
+
+	t.Run("unknown profile defaults to chrome", func(t *testing.T) {
+		got := quicConfigFor("does-not-exist")
+		want := quicProfiles["chrome"]
+		if got.InitialConnectionReceiveWindow != want.initialConnWindow {
+			t.Errorf("quicConfigFor(unknown).InitialConnectionReceiveWindow = %v, want chrome's %v", got.InitialConnectionReceiveWindow, want.initialConnWindow)
+		}
+	})
+
+	t.Run("empty defaults to chrome", func(t *testing.T) {
+		got := quicConfigFor("")
+		want := quicProfiles["chrome"]
+		if got.MaxStreamReceiveWindow != want.maxStreamWindow {
+			t.Errorf("quicConfigFor(\"\").MaxStreamReceiveWindow = %v, want chrome's %v", got.MaxStreamReceiveWindow, want.maxStreamWindow)
+		}
+	})
+}
+
+func TestAltSvcCacheConcurrentAccess(t *testing.T) {
+	cache := newAltSvcCache()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			cache.record("example.com")
+		}()
+		go func() {
+			defer wg.Done()
+			cache.has("example.com")
+		}()
+	}
+	wg.Wait()
+
+	if !cache.has("example.com") {
+		t.Error("expected example.com to be recorded after concurrent writes")
+	}
+	if cache.has("other.example.com") {
+		t.Error("expected an unrecorded host to report false")
+	}
+}
@@ -0,0 +1,151 @@
+package cycletls
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	nethttp "net/http"
+	"strconv"
+	"time"
+)
+
+// Retry configures automatic retries of transient failures (connection
+// resets, TLS handshake timeouts, 429/503 responses) for Do. A zero value
+// disables retries, matching the pre-existing single-attempt behavior.
+type Retry struct {
+	MaxAttempts    int           `json:"maxAttempts"`
+	InitialBackoff time.Duration `json:"initialBackoff"`
+	MaxBackoff     time.Duration `json:"maxBackoff"`
+	Multiplier     float64       `json:"multiplier"`
+	Jitter         bool          `json:"jitter"`
+	RetryOn        []int         `json:"retryOn"`
+	// ShouldRetry, if set, overrides the default retry policy entirely.
+	ShouldRetry func(*Response, error) bool `json:"-"`
+}
+
+// dispatchWithRetry runs dispatcher, retrying per request.Options.Retry
+// with decorrelated-jitter backoff. Each attempt rebuilds the request from
+// request.Options.Body, which is already a buffered string, so replaying a
+// POST is safe.
+func (client *CycleTLS) dispatchWithRetry(request cycleTLSRequest) (response Response, err error) {
+	retry := request.Options.Retry
+	maxAttempts := retry.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	ctx := request.Options.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var prevBackoff time.Duration
+	for attempt := 1; ; attempt++ {
+		res := client.processRequest(request)
+		response, err = dispatcher(res)
+
+		if attempt >= maxAttempts || !shouldRetry(retry, response, err) {
+			if err != nil {
+				log.Print("Request Failed: " + err.Error())
+			}
+			return response, err
+		}
+
+		wait := retryAfter(response)
+		if wait <= 0 {
+			wait = decorrelatedJitterBackoff(retry, prevBackoff)
+		}
+		prevBackoff = wait
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return response, ctx.Err()
+		}
+	}
+}
+
+// shouldRetry applies the default retry policy, or request.Options.Retry.ShouldRetry
+// when the caller supplied one. Under the default policy, context
+// cancellation is never retried, and 4xx responses are retried only for
+// 408, 429 and codes listed in RetryOn.
+func shouldRetry(retry Retry, response Response, err error) bool {
+	if retry.ShouldRetry != nil {
+		return retry.ShouldRetry(&response, err)
+	}
+
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	if response.Status == nethttp.StatusRequestTimeout || response.Status == nethttp.StatusTooManyRequests {
+		return true
+	}
+	for _, code := range retry.RetryOn {
+		if response.Status == code {
+			return true
+		}
+	}
+	return response.Status >= 500
+}
+
+// retryAfter parses a Retry-After response header, which may be either
+// delta-seconds or an HTTP-date, returning 0 when absent or unparsable.
+func retryAfter(response Response) time.Duration {
+	value := response.Headers["Retry-After"]
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if when, err := nethttp.ParseTime(value); err == nil {
+		return time.Until(when)
+	}
+	return 0
+}
+
+// decorrelatedJitterBackoff implements the decorrelated-jitter algorithm:
+// sleep = min(MaxBackoff, random(InitialBackoff, prev*Multiplier)) when
+// Jitter is set, and min(MaxBackoff, max(InitialBackoff, prev*Multiplier))
+// without it. The first wait (prev == 0) is InitialBackoff capped at
+// MaxBackoff. Zero fields default to 500ms, 30s and a multiplier of 3.
+func decorrelatedJitterBackoff(retry Retry, prev time.Duration) time.Duration {
+	initial := retry.InitialBackoff
+	if initial <= 0 {
+		initial = 500 * time.Millisecond
+	}
+	maxBackoff := retry.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = 30 * time.Second
+	}
+	multiplier := retry.Multiplier
+	if multiplier <= 0 {
+		multiplier = 3
+	}
+
+	if prev <= 0 {
+		wait := initial
+		if wait > maxBackoff {
+			wait = maxBackoff
+		}
+		return wait
+	}
+
+	upper := time.Duration(float64(prev) * multiplier)
+	if upper < initial {
+		upper = initial
+	}
+
+	wait := upper
+	if retry.Jitter {
+		if span := upper - initial; span > 0 {
+			wait = initial + time.Duration(rand.Int63n(int64(span)))
+		}
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
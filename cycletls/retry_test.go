@@ -0,0 +1,99 @@
+package cycletls
+
+import (
+	"context"
+	"errors"
+	nethttp "net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{"absent", "", 0},
+		{"delta seconds", "120", 120 * time.Second},
+		{"unparsable", "not-a-date", 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			response := Response{Headers: map[string]string{"Retry-After": tc.header}}
+			if got := retryAfter(response); got != tc.want {
+				t.Errorf("retryAfter(%q) = %v, want %v", tc.header, got, tc.want)
+			}
+		})
+	}
+
+	t.Run("http-date in the future", func(t *testing.T) {
+		when := time.Now().Add(90 * time.Second).UTC()
+		response := Response{Headers: map[string]string{"Retry-After": when.Format(nethttp.TimeFormat)}}
+		got := retryAfter(response)
+		if got <= 0 || got > 91*time.Second {
+			t.Errorf("retryAfter(http-date) = %v, want ~90s", got)
+		}
+	})
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	retry := Retry{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+
+	t.Run("first attempt returns initial", func(t *testing.T) {
+		if got := decorrelatedJitterBackoff(retry, 0); got != retry.InitialBackoff {
+			t.Errorf("decorrelatedJitterBackoff(first) = %v, want %v", got, retry.InitialBackoff)
+		}
+	})
+
+	t.Run("grows but never exceeds MaxBackoff", func(t *testing.T) {
+		prev := retry.InitialBackoff
+		for i := 0; i < 20; i++ {
+			wait := decorrelatedJitterBackoff(retry, prev)
+			if wait > retry.MaxBackoff {
+				t.Fatalf("decorrelatedJitterBackoff = %v, exceeds MaxBackoff %v", wait, retry.MaxBackoff)
+			}
+			if wait < retry.InitialBackoff {
+				t.Fatalf("decorrelatedJitterBackoff = %v, below InitialBackoff %v", wait, retry.InitialBackoff)
+			}
+			prev = wait
+		}
+	})
+
+	t.Run("defaults applied for zero-value Retry", func(t *testing.T) {
+		wait := decorrelatedJitterBackoff(Retry{}, 0)
+		if wait <= 0 {
+			t.Errorf("decorrelatedJitterBackoff(zero value) = %v, want > 0", wait)
+		}
+	})
+}
+
+func TestShouldRetry(t *testing.T) {
+	cases := []struct {
+		name     string
+		retry    Retry
+		response Response
+		err      error
+		want     bool
+	}{
+		{"network error retries", Retry{}, Response{}, errors.New("connection reset"), true},
+		{"context canceled does not retry", Retry{}, Response{}, context.Canceled, false},
+		{"408 retries", Retry{}, Response{Status: 408}, nil, true},
+		{"429 retries", Retry{}, Response{Status: 429}, nil, true},
+		{"500 retries", Retry{}, Response{Status: 500}, nil, true},
+		{"other 4xx does not retry", Retry{}, Response{Status: 404}, nil, false},
+		{"custom RetryOn", Retry{RetryOn: []int{418}}, Response{Status: 418}, nil, true},
+		{
+			"ShouldRetry overrides default policy",
+			Retry{ShouldRetry: func(r *Response, err error) bool { return r.Status == 404 }},
+			Response{Status: 404}, nil, true,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := shouldRetry(tc.retry, tc.response, tc.err); got != tc.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
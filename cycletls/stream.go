@@ -0,0 +1,113 @@
+package cycletls
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+// StreamResponse is the streaming counterpart of Response: Body is an
+// io.ReadCloser the caller drains (and must Close) instead of a string.
+type StreamResponse struct {
+	RequestID string
+	Status    int
+	Headers   map[string]string
+	Body      io.ReadCloser
+}
+
+// DoStream sends the request like Do but hands the response body back
+// unread, as an io.ReadCloser. Unlike Do, it does not consult Cache and does
+// not retry per Options.Retry. A registered TransferAdapter's Response body
+// is already in memory and is wrapped as is.
+func (client *CycleTLS) DoStream(URL string, options Options, Method string) (response StreamResponse, err error) {
+	options.URL = URL
+	options.Method = Method
+	opt := cycleTLSRequest{"cycleTLSRequest", options}
+
+	if adapter, ok := client.adapterFor(opt.Options.URL); ok {
+		plain, err := adapter.RoundTrip(opt.Options)
+		if err != nil {
+			return StreamResponse{}, err
+		}
+		return StreamResponse{
+			RequestID: plain.RequestID,
+			Status:    plain.Status,
+			Headers:   plain.Headers,
+			Body:      ioutil.NopCloser(strings.NewReader(plain.Body)),
+		}, nil
+	}
+
+	res := client.processRequest(opt)
+	return streamDispatcher(res)
+}
+
+func streamDispatcher(res fullRequest) (response StreamResponse, err error) {
+	resp, err := res.client.Do(res.req)
+	if err != nil {
+		parsedError := parseError(err)
+		return StreamResponse{
+			RequestID: res.options.RequestID,
+			Status:    parsedError.StatusCode,
+			Headers:   make(map[string]string),
+			Body:      ioutil.NopCloser(strings.NewReader(parsedError.ErrorMsg + "-> \n" + err.Error())),
+		}, nil //normally return error here
+	}
+
+	if needsNegotiateResponse(res.options.Options.Auth, resp) {
+		resp.Body.Close()
+		if token, tokenErr := negotiateToken(res.options.Options.Auth); tokenErr == nil {
+			if req2, buildErr := reissueWithAuth(res, authorizationHeaderFor(resp), string(AuthNegotiate)+" "+token); buildErr == nil {
+				if resp2, doErr := res.client.Do(req2); doErr == nil {
+					resp = resp2
+				}
+			}
+		}
+	}
+
+	if jar := res.client.Jar; jar != nil {
+		jar.SetCookies(res.req.URL, resp.Cookies())
+	}
+	if res.altSvcCache != nil {
+		if altSvc := resp.Header.Get("Alt-Svc"); altSvc != "" && hasH3AltSvc(altSvc) {
+			res.altSvcCache.record(res.req.URL.Hostname())
+		}
+	}
+
+	encoding := resp.Header["Content-Encoding"]
+	content := resp.Header["Content-Type"]
+
+	body, err := DecompressStream(resp.Body, encoding, content)
+	if err != nil {
+		resp.Body.Close()
+		return response, err
+	}
+
+	headers := make(map[string]string)
+	for name, values := range resp.Header {
+		if name == "Set-Cookie" {
+			headers[name] = strings.Join(values, "/,/")
+		} else {
+			for _, value := range values {
+				headers[name] = value
+			}
+		}
+	}
+
+	return StreamResponse{RequestID: res.options.RequestID, Status: resp.StatusCode, Headers: headers, Body: body}, nil
+}
+
+// dispatcher drains streamDispatcher's body into a string for Do/Queue callers.
+func dispatcher(res fullRequest) (response Response, err error) {
+	streamResponse, err := streamDispatcher(res)
+	if err != nil {
+		return response, err
+	}
+	defer streamResponse.Body.Close()
+
+	bodyBytes, err := ioutil.ReadAll(streamResponse.Body)
+	if err != nil {
+		return response, err
+	}
+
+	return Response{streamResponse.RequestID, streamResponse.Status, string(bodyBytes), streamResponse.Headers}, nil
+}